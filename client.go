@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// runClient submits a scan to a running `reconbulk serve` daemon and
+// streams its stage events to stdout, giving scripts that already call
+// `reconbulk domain resolvers` a drop-in daemon-backed equivalent.
+func runClient(args []string) {
+	fs := flag.NewFlagSet("client", flag.ExitOnError)
+	server := fs.String("server", "http://localhost:8088", "reconbulk daemon base URL")
+	stages := fs.String("only", "", "comma-separated list of stages to run (default: all)")
+	fs.Usage = func() {
+		fmt.Println("Usage : ./reconbulk client [flags] domain resolvers_list")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	domain := fs.Arg(0)
+	resolvers := fs.Arg(1)
+
+	var stageList []string
+	if *stages != "" {
+		stageList = strings.Split(*stages, ",")
+	}
+
+	job, err := submitJob(*server, domain, resolvers, stageList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "submit job:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("submitted job %s for %s\n", job.ID, job.Domain)
+
+	if err := streamJobEvents(*server, job.ID); err != nil {
+		fmt.Fprintln(os.Stderr, "stream job events:", err)
+		os.Exit(1)
+	}
+
+	final, err := fetchJob(*server, job.ID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fetch final job status:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("job %s finished: %s (results: %s)\n", final.ID, final.Status, final.ResultDir)
+}
+
+func submitJob(server, domain, resolvers string, stages []string) (*Job, error) {
+	body, err := json.Marshal(createJobRequest{Domain: domain, Resolvers: resolvers, Stages: stages})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(server+"/jobs", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("daemon returned %s", resp.Status)
+	}
+
+	var job Job
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func fetchJob(server, id string) (*Job, error) {
+	resp, err := http.Get(server + "/jobs/" + id)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon returned %s", resp.Status)
+	}
+
+	var job Job
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func streamJobEvents(server, id string) error {
+	resp, err := http.Get(server + "/jobs/" + id + "/events")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if event := strings.TrimPrefix(line, "data: "); event != line {
+			fmt.Println(event)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/load"
+)
+
+// Governor throttles how many external tools may run at once, recomputing
+// its capacity from the host's load average so recon doesn't thrash small
+// VPS boxes. Stages call Acquire before starting a tool and Release when it
+// finishes; the capacity itself is refreshed in the background.
+type Governor struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	running     int
+	capacity    int
+	maxParallel int
+	targetLoad  float64
+}
+
+// newGovernor starts a governor and its background load poller. maxParallel
+// of 0 means "no explicit ceiling beyond what the load factor computes".
+func newGovernor(maxParallel int, targetLoad float64, pollInterval time.Duration) *Governor {
+	g := &Governor{
+		maxParallel: maxParallel,
+		targetLoad:  targetLoad,
+		capacity:    runtime.NumCPU(),
+	}
+	g.cond = sync.NewCond(&g.mu)
+	g.refreshCapacity()
+	go g.pollLoop(pollInterval)
+	return g
+}
+
+// refreshCapacity recomputes the semaphore's capacity as
+// max(1, floor(NumCPU * targetLoadFactor - load1)), clipped to maxParallel.
+func (g *Governor) refreshCapacity() {
+	capacity := runtime.NumCPU()
+	avg, err := load.Avg()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to read load average: %v\n", err)
+	} else {
+		computed := int(math.Floor(float64(runtime.NumCPU())*g.targetLoad - avg.Load1))
+		if computed < 1 {
+			computed = 1
+		}
+		capacity = computed
+	}
+	if g.maxParallel > 0 && capacity > g.maxParallel {
+		capacity = g.maxParallel
+	}
+
+	g.mu.Lock()
+	g.capacity = capacity
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
+
+func (g *Governor) pollLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		g.refreshCapacity()
+	}
+}
+
+// Acquire blocks until a concurrency slot is free.
+func (g *Governor) Acquire() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for g.running >= g.capacity {
+		g.cond.Wait()
+	}
+	g.running++
+}
+
+// Release frees a concurrency slot and wakes any waiters.
+func (g *Governor) Release() {
+	g.mu.Lock()
+	g.running--
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
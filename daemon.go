@@ -0,0 +1,439 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// JobStatus tracks a queued scan through the daemon's worker pool. It
+// mirrors StageStatus's naming but applies to the job as a whole rather
+// than one stage of it.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is one daemon-accepted scan request, persisted to the BoltDB job
+// store so it survives a daemon restart.
+type Job struct {
+	ID            string    `json:"id"`
+	Domain        string    `json:"domain"`
+	ResolversFile string    `json:"resolvers_file"`
+	Stages        []string  `json:"stages,omitempty"`
+	Status        JobStatus `json:"status"`
+	ResultDir     string    `json:"result_dir,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+const jobsBucket = "jobs"
+
+// JobStore is a BoltDB-backed key/value store of Jobs, keyed by job ID.
+type JobStore struct {
+	db *bbolt.DB
+}
+
+func openJobStore(path string) (*JobStore, error) {
+	db, err := bbolt.Open(path, 0644, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open job store: %w", err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(jobsBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init job store: %w", err)
+	}
+	return &JobStore{db: db}, nil
+}
+
+func (s *JobStore) Close() error { return s.db.Close() }
+
+func (s *JobStore) Put(job *Job) error {
+	job.UpdatedAt = time.Now()
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(jobsBucket)).Put([]byte(job.ID), data)
+	})
+}
+
+func (s *JobStore) Get(id string) (*Job, error) {
+	var job Job
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket([]byte(jobsBucket)).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("job %q not found", id)
+		}
+		return json.Unmarshal(data, &job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *JobStore) Delete(id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(jobsBucket)).Delete([]byte(id))
+	})
+}
+
+// JobQueue is a small worker pool that drains queued jobs and runs each one
+// through the same Run/Manifest/stage-function machinery the one-shot CLI
+// uses, so the daemon and `reconbulk <domain> <resolvers>` never drift.
+type JobQueue struct {
+	store    *JobStore
+	reconDir string
+	governor *Governor
+	pending  chan string
+
+	subsMu sync.Mutex
+	subs   map[string][]chan string
+}
+
+func newJobQueue(store *JobStore, reconDir string, workers int, governor *Governor) *JobQueue {
+	q := &JobQueue{
+		store:    store,
+		reconDir: reconDir,
+		governor: governor,
+		pending:  make(chan string, 256),
+		subs:     make(map[string][]chan string),
+	}
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+	return q
+}
+
+// Submit persists a freshly created job and enqueues it for a worker.
+func (q *JobQueue) Submit(job *Job) error {
+	job.Status = JobQueued
+	job.CreatedAt = time.Now()
+	if err := q.store.Put(job); err != nil {
+		return err
+	}
+	q.pending <- job.ID
+	return nil
+}
+
+func (q *JobQueue) worker() {
+	for id := range q.pending {
+		q.runJob(id)
+	}
+}
+
+func (q *JobQueue) publish(jobID, event string) {
+	q.subsMu.Lock()
+	defer q.subsMu.Unlock()
+	for _, ch := range q.subs[jobID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (q *JobQueue) subscribe(jobID string) chan string {
+	ch := make(chan string, 16)
+	q.subsMu.Lock()
+	q.subs[jobID] = append(q.subs[jobID], ch)
+	q.subsMu.Unlock()
+	return ch
+}
+
+func (q *JobQueue) unsubscribe(jobID string, ch chan string) {
+	q.subsMu.Lock()
+	defer q.subsMu.Unlock()
+	subs := q.subs[jobID]
+	for i, s := range subs {
+		if s == ch {
+			q.subs[jobID] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+}
+
+// runJob drives one job through the full pipeline, reusing the exact stage
+// functions and Run/Manifest bookkeeping runScan uses, so results produced
+// by the daemon land in the same layout a CLI run would produce.
+func (q *JobQueue) runJob(id string) {
+	job, err := q.store.Get(id)
+	if err != nil {
+		logger.Error("job vanished before it could run", "job_id", id, "error", err)
+		return
+	}
+
+	job.Status = JobRunning
+	dt := time.Now().Format("2006-01-02.15.04.05")
+	job.ResultDir = filepath.Join(q.reconDir, fmt.Sprintf("results/%s-%s", job.Domain, dt))
+	os.MkdirAll(job.ResultDir, os.ModePerm)
+	q.store.Put(job)
+	q.publish(id, "running")
+
+	manifest := newManifest(job.Domain, job.ResolversFile, job.ResultDir)
+	if err := manifest.save(); err != nil {
+		logger.Error("failed to save manifest", "job_id", id, "error", err)
+	}
+
+	run := &Run{
+		Domain:        job.Domain,
+		ResolversFile: job.ResolversFile,
+		ResultDir:     job.ResultDir,
+		Manifest:      manifest,
+		Only:          stageSet(strings.Join(job.Stages, ",")),
+		Logger:        logger.With("job_id", id),
+	}
+
+	registry := buildSourceRegistry(job.ResolversFile)
+
+	// Local-audit only makes sense against a host the operator controls via
+	// shell access, which a remotely submitted job never has; skip it
+	// outright instead of leaving it stuck at "pending".
+	manifest.skipStage(StageLocalAudit)
+
+	run.runStage(StageSubdomainEnum, "findSubdomains", func() ([]string, error) {
+		return findSubdomains(job.Domain, job.ResultDir, registry, config.Sources.Enabled, q.governor), nil
+	})
+
+	run.runStage(StageCRT, "scanCRT", func() ([]string, error) {
+		if err := scanCRT(job.Domain, job.ResultDir); err != nil {
+			return nil, err
+		}
+		return []string{
+			filepath.Join(job.ResultDir, fmt.Sprintf("%s.crt.txt", job.Domain)),
+			filepath.Join(job.ResultDir, fmt.Sprintf("crtsh_%s.jsonl", job.Domain)),
+		}, nil
+	})
+
+	run.runStage(StageCombine, "combineSubdomains", func() ([]string, error) {
+		subdomainFiles := make([]string, 0, len(registry.Enabled(config.Sources.Enabled))+1)
+		for _, src := range registry.Enabled(config.Sources.Enabled) {
+			subdomainFiles = append(subdomainFiles, filepath.Join(job.ResultDir, fmt.Sprintf("%s_%s.txt", src.Name(), job.Domain)))
+		}
+		subdomainFiles = append(subdomainFiles, filepath.Join(job.ResultDir, fmt.Sprintf("%s.crt.txt", job.Domain)))
+		if err := combineSubdomains(job.Domain, job.ResultDir, subdomainFiles); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(job.ResultDir, fmt.Sprintf("%s.subdomains.txt", job.Domain))}, nil
+	})
+
+	run.runStage(StageMassdns, "findIPs", func() ([]string, error) {
+		if err := findIPs(job.Domain, job.ResolversFile, job.ResultDir); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(job.ResultDir, fmt.Sprintf("%s.ips.txt", job.Domain))}, nil
+	})
+
+	run.runStage(StageHttpx, "scanHttpx", func() ([]string, error) {
+		if err := scanHttpx(job.Domain, job.ResultDir); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(job.ResultDir, fmt.Sprintf("sorted_httpx_%s.txt", job.Domain))}, nil
+	})
+
+	run.runStage(StageNaabu, "scanNaabu", func() ([]string, error) {
+		if err := scanNaabu(job.Domain, job.ResultDir); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(job.ResultDir, fmt.Sprintf("naabu_%s.txt", job.Domain))}, nil
+	})
+
+	run.runStage(StageNuclei, "scanNuclei", func() ([]string, error) {
+		if err := scanNuclei(job.Domain, job.ResultDir); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(job.ResultDir, fmt.Sprintf("nuclei_%s.txt", job.Domain))}, nil
+	})
+
+	for _, name := range allStages {
+		q.publish(id, fmt.Sprintf("stage:%s:%s", name, manifest.Stages[name].Status))
+	}
+
+	job.Status = JobCompleted
+	for _, rec := range manifest.Stages {
+		if rec.Status == StatusFailed {
+			job.Status = JobFailed
+			job.Error = rec.Error
+			break
+		}
+	}
+	q.store.Put(job)
+	q.publish(id, "done")
+}
+
+// runServe starts the HTTP+JSON daemon: a BoltDB-backed job queue in front
+// of a worker pool, so a CI pipeline or web UI can drive reconbulk without
+// shelling out to a one-shot CLI invocation per domain.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8088", "address to listen on")
+	dbPath := fs.String("db", "reconbulk.db", "path to the BoltDB job queue file")
+	workers := fs.Int("workers", 2, "number of concurrent job workers")
+	maxParallel := fs.Int("max-parallel", 0, "hard ceiling on concurrent external tools across all jobs (0 = no explicit ceiling)")
+	targetLoad := fs.Float64("target-load", 0.8, "target load factor used to size the concurrency governor")
+	loadPoll := fs.Duration("load-poll", 5*time.Second, "how often to re-read the host load average")
+	logLevel := fs.String("log-level", "info", "log level: debug, info, warn, error")
+	logFormat := fs.String("log-format", "text", "log format: text or json")
+	fs.Parse(args)
+
+	logger = newLogger(*logLevel, *logFormat, "daemon")
+	loadConfig("config.json")
+
+	store, err := openJobStore(*dbPath)
+	checkErr(err)
+	defer store.Close()
+
+	governor := newGovernor(*maxParallel, *targetLoad, *loadPoll)
+	reconDir := filepath.Join(os.Getenv("HOME"), "recon")
+	queue := newJobQueue(store, reconDir, *workers, governor)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleCreateJob(w, r, queue)
+	})
+	mux.HandleFunc("/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		handleJobRoute(w, r, store, queue)
+	})
+
+	logger.Info("reconbulk daemon listening", "addr", *addr, "db", *dbPath, "workers", *workers)
+	checkErr(http.ListenAndServe(*addr, mux))
+}
+
+type createJobRequest struct {
+	Domain    string   `json:"domain"`
+	Resolvers string   `json:"resolvers"`
+	Stages    []string `json:"stages,omitempty"`
+}
+
+// domainPattern accepts only what job.ResultDir's filepath.Join can safely
+// turn into a single path segment: dot-separated alphanumeric-and-hyphen
+// labels. It rejects "/" and ".." outright, which matters here because
+// job.Domain comes straight off the unauthenticated POST /jobs body and
+// flows into both job.ResultDir (daemon.go's runJob) and the results file
+// handler's path join.
+var domainPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+
+func handleCreateJob(w http.ResponseWriter, r *http.Request, queue *JobQueue) {
+	var req createJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Domain == "" {
+		http.Error(w, "domain is required", http.StatusBadRequest)
+		return
+	}
+	if !domainPattern.MatchString(req.Domain) {
+		http.Error(w, "domain is not a valid hostname", http.StatusBadRequest)
+		return
+	}
+
+	job := &Job{ID: newTraceID(), Domain: req.Domain, ResolversFile: req.Resolvers, Stages: req.Stages}
+	if err := queue.Submit(job); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// handleJobRoute dispatches everything under /jobs/: GET/DELETE on the job
+// itself, GET .../results/<file> to serve one output file, and GET
+// .../events for an SSE stream of job events: "running" when the job
+// starts, each stage's final status once the whole pipeline has finished,
+// then "done".
+func handleJobRoute(w http.ResponseWriter, r *http.Request, store *JobStore, queue *JobQueue) {
+	path := strings.TrimPrefix(r.URL.Path, "/jobs/")
+	parts := strings.SplitN(path, "/", 3)
+	if parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	jobID := parts[0]
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		job, err := store.Get(jobID)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(job)
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if err := store.Delete(jobID); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 3 && parts[1] == "results" && r.Method == http.MethodGet:
+		job, err := store.Get(jobID)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join(job.ResultDir, filepath.Base(parts[2])))
+
+	case len(parts) == 2 && parts[1] == "events" && r.Method == http.MethodGet:
+		handleJobEvents(w, r, queue, jobID)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleJobEvents(w http.ResponseWriter, r *http.Request, queue *JobQueue, jobID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := queue.subscribe(jobID)
+	defer queue.unsubscribe(jobID, ch)
+
+	for {
+		select {
+		case event := <-ch:
+			fmt.Fprintf(w, "data: %s\n\n", event)
+			flusher.Flush()
+			if event == "done" {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
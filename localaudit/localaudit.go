@@ -0,0 +1,306 @@
+// Package localaudit enumerates the host's own listening TCP/UDP sockets,
+// giving an operator with shell access on the scan target a ground-truth
+// port list even where naabu is blocked by a local firewall.
+package localaudit
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+func execCommand(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Listener is one locally bound socket.
+type Listener struct {
+	Proto   string `json:"proto"`
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+	PID     int    `json:"pid,omitempty"`
+	Program string `json:"program,omitempty"`
+}
+
+// Gather returns the host's listening sockets: a /proc/net reader on Linux,
+// netstat/lsof elsewhere.
+func Gather() ([]Listener, error) {
+	if runtime.GOOS == "linux" {
+		listeners, err := gatherFromProc()
+		if err == nil {
+			return listeners, nil
+		}
+		// fall through to the netstat/lsof fallback if /proc isn't readable
+		// (e.g. inside a restrictive container).
+	}
+	return gatherFromCommand()
+}
+
+// tcpListenState is the value of st() in /proc/net/tcp{,6} for a socket in
+// LISTEN state.
+const tcpListenState = "0A"
+
+var procNetFiles = []struct {
+	path  string
+	proto string
+	// hasState is false for udp, which has no equivalent "listening" state;
+	// any bound udp socket is reported.
+	hasState bool
+}{
+	{"/proc/net/tcp", "tcp", true},
+	{"/proc/net/tcp6", "tcp6", true},
+	{"/proc/net/udp", "udp", false},
+	{"/proc/net/udp6", "udp6", false},
+}
+
+func gatherFromProc() ([]Listener, error) {
+	inodeToPID := socketInodesByPID()
+
+	var listeners []Listener
+	for _, f := range procNetFiles {
+		entries, err := parseProcNetFile(f.path, f.proto, f.hasState)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for i := range entries {
+			if pid, ok := inodeToPID[entries[i].inode]; ok {
+				entries[i].listener.PID = pid
+				entries[i].listener.Program = programName(pid)
+			}
+			listeners = append(listeners, entries[i].listener)
+		}
+	}
+	return listeners, nil
+}
+
+type procNetEntry struct {
+	listener Listener
+	inode    string
+}
+
+func parseProcNetFile(path, proto string, hasState bool) ([]procNetEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []procNetEntry
+	lines := strings.Split(string(data), "\n")
+	for _, line := range lines[1:] { // skip the header row
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+
+		if hasState && fields[3] != tcpListenState {
+			continue
+		}
+
+		addr, port, err := parseHexAddr(fields[1])
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, procNetEntry{
+			listener: Listener{Proto: proto, Address: addr, Port: port},
+			inode:    fields[9],
+		})
+	}
+	return entries, nil
+}
+
+// parseHexAddr decodes /proc/net/{tcp,udp}{,6}'s "ADDR:PORT" column, e.g.
+// "0100007F:1F90" (127.0.0.1:8080) or the IPv6 "00000000000000000000000001000000:1F90"
+// ([::1]:8080).
+func parseHexAddr(addr string) (string, int, error) {
+	parts := strings.SplitN(addr, ":", 2)
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("malformed address %q", addr)
+	}
+
+	port, err := strconv.ParseInt(parts[1], 16, 32)
+	if err != nil {
+		return "", 0, err
+	}
+
+	ip, err := decodeProcNetAddr(parts[0])
+	if err != nil {
+		return "", 0, err
+	}
+
+	return ip.String(), int(port), nil
+}
+
+// decodeProcNetAddr decodes the hex address column itself. The kernel
+// writes it as a sequence of 32-bit words in host (little-endian) byte
+// order: one word for IPv4, four for IPv6. Each word needs byte-swapping
+// independently, not the column as a single little-endian blob, or IPv6
+// addresses like "::1" come out byte-reversed instead of in network order.
+func decodeProcNetAddr(hexAddr string) (net.IP, error) {
+	raw, err := hex.DecodeString(hexAddr)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || len(raw)%4 != 0 {
+		return nil, fmt.Errorf("unexpected address length %d", len(raw))
+	}
+
+	ip := make(net.IP, 0, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		word := raw[i : i+4]
+		ip = append(ip, word[3], word[2], word[1], word[0])
+	}
+	return ip, nil
+}
+
+// socketInodesByPID walks /proc/<pid>/fd to map each open socket inode back
+// to the PID that holds it.
+func socketInodesByPID() map[string]int {
+	result := make(map[string]int)
+
+	procEntries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return result
+	}
+
+	for _, procEntry := range procEntries {
+		pid, err := strconv.Atoi(procEntry.Name())
+		if err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join("/proc", procEntry.Name(), "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if inode, ok := socketInode(link); ok {
+				result[inode] = pid
+			}
+		}
+	}
+	return result
+}
+
+func socketInode(link string) (string, bool) {
+	const prefix = "socket:["
+	if !strings.HasPrefix(link, prefix) || !strings.HasSuffix(link, "]") {
+		return "", false
+	}
+	return link[len(prefix) : len(link)-1], true
+}
+
+func programName(pid int) string {
+	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// gatherFromCommand is the non-Linux fallback, shelling out to whichever of
+// netstat or lsof is available.
+func gatherFromCommand() ([]Listener, error) {
+	if listeners, err := runNetstat(); err == nil {
+		return listeners, nil
+	}
+	return runLsof()
+}
+
+func runNetstat() ([]Listener, error) {
+	out, err := execCommand("netstat", "-an")
+	if err != nil {
+		return nil, err
+	}
+	return parseNetstatOutput(out), nil
+}
+
+func runLsof() ([]Listener, error) {
+	out, err := execCommand("lsof", "-i", "-P", "-n")
+	if err != nil {
+		return nil, err
+	}
+	return parseLsofOutput(out), nil
+}
+
+func parseNetstatOutput(out string) []Listener {
+	var listeners []Listener
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		proto := strings.ToLower(fields[0])
+		if !strings.HasPrefix(proto, "tcp") && !strings.HasPrefix(proto, "udp") {
+			continue
+		}
+		if strings.HasPrefix(proto, "tcp") && !strings.Contains(scanner.Text(), "LISTEN") {
+			continue
+		}
+		addr, port, ok := splitHostPort(fields[3])
+		if !ok {
+			continue
+		}
+		listeners = append(listeners, Listener{Proto: proto, Address: addr, Port: port})
+	}
+	return listeners
+}
+
+func parseLsofOutput(out string) []Listener {
+	var listeners []Listener
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 || fields[0] == "COMMAND" {
+			continue
+		}
+		if !strings.Contains(scanner.Text(), "LISTEN") && !strings.Contains(fields[7], "UDP") {
+			continue
+		}
+		addr, port, ok := splitHostPort(fields[8])
+		if !ok {
+			continue
+		}
+		listeners = append(listeners, Listener{
+			Proto:   strings.ToLower(fields[7]),
+			Address: addr,
+			Port:    port,
+			Program: fields[0],
+		})
+	}
+	return listeners
+}
+
+func splitHostPort(hostport string) (string, int, bool) {
+	i := strings.LastIndex(hostport, ":")
+	if i < 0 {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(hostport[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return hostport[:i], port, true
+}
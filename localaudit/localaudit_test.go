@@ -0,0 +1,34 @@
+package localaudit
+
+import "testing"
+
+func TestParseHexAddr(t *testing.T) {
+	cases := []struct {
+		name     string
+		hex      string
+		wantAddr string
+		wantPort int
+	}{
+		{"ipv4 loopback", "0100007F:1F90", "127.0.0.1", 8080},
+		{"ipv6 loopback", "00000000000000000000000001000000:1F90", "::1", 8080},
+		{"ipv6 unspecified", "00000000000000000000000000000000:0050", "::", 80},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addr, port, err := parseHexAddr(c.hex)
+			if err != nil {
+				t.Fatalf("parseHexAddr(%q) returned error: %v", c.hex, err)
+			}
+			if addr != c.wantAddr || port != c.wantPort {
+				t.Fatalf("parseHexAddr(%q) = (%q, %d), want (%q, %d)", c.hex, addr, port, c.wantAddr, c.wantPort)
+			}
+		})
+	}
+}
+
+func TestParseHexAddrMalformed(t *testing.T) {
+	if _, _, err := parseHexAddr("not-an-address"); err == nil {
+		t.Fatal("expected an error for a malformed address")
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StageName identifies one step of the recon pipeline. The set mirrors the
+// calls made from runScan: subdomain enumeration fans out into its own stage so
+// it can be resumed independently of the external tools it spawns.
+type StageName string
+
+const (
+	StageLocalAudit    StageName = "local_audit"
+	StageSubdomainEnum StageName = "subdomain_enum"
+	StageCRT           StageName = "crtsh"
+	StageCombine       StageName = "combine"
+	StageMassdns       StageName = "massdns"
+	StageHttpx         StageName = "httpx"
+	StageNaabu         StageName = "naabu"
+	StageNuclei        StageName = "nuclei"
+)
+
+var allStages = []StageName{
+	StageLocalAudit,
+	StageSubdomainEnum,
+	StageCRT,
+	StageCombine,
+	StageMassdns,
+	StageHttpx,
+	StageNaabu,
+	StageNuclei,
+}
+
+type StageStatus string
+
+const (
+	StatusPending   StageStatus = "pending"
+	StatusRunning   StageStatus = "running"
+	StatusCompleted StageStatus = "completed"
+	StatusFailed    StageStatus = "failed"
+	StatusSkipped   StageStatus = "skipped"
+)
+
+// StageRecord tracks the progress of a single stage across the lifetime of a
+// run, so a crashed or interrupted scan can be resumed without redoing work.
+type StageRecord struct {
+	Name      StageName   `json:"name"`
+	Status    StageStatus `json:"status"`
+	Command   string      `json:"command,omitempty"`
+	StartedAt time.Time   `json:"started_at,omitempty"`
+	EndedAt   time.Time   `json:"ended_at,omitempty"`
+	ExitCode  int         `json:"exit_code"`
+	Outputs   []string    `json:"outputs,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// Manifest is the persisted state of one recon run, written to
+// <resultDir>/manifest.json after every stage transition.
+type Manifest struct {
+	Domain        string                     `json:"domain"`
+	ResultDir     string                     `json:"result_dir"`
+	ResolversFile string                     `json:"resolvers_file"`
+	TraceID       string                     `json:"trace_id"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	UpdatedAt     time.Time                  `json:"updated_at"`
+	Stages        map[StageName]*StageRecord `json:"stages"`
+
+	mu   sync.Mutex
+	path string
+}
+
+func manifestPath(resultDir string) string {
+	return filepath.Join(resultDir, "manifest.json")
+}
+
+// newTraceID returns a short random hex id used to correlate every log line
+// and manifest write for one run.
+func newTraceID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("t%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+func newManifest(domain, resolversFile, resultDir string) *Manifest {
+	now := time.Now()
+	m := &Manifest{
+		Domain:        domain,
+		ResultDir:     resultDir,
+		ResolversFile: resolversFile,
+		TraceID:       newTraceID(),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Stages:        make(map[StageName]*StageRecord),
+		path:          manifestPath(resultDir),
+	}
+	for _, name := range allStages {
+		m.Stages[name] = &StageRecord{Name: name, Status: StatusPending}
+	}
+	return m
+}
+
+func loadManifest(resultDir string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(manifestPath(resultDir))
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	m.path = manifestPath(resultDir)
+	if m.Stages == nil {
+		m.Stages = make(map[StageName]*StageRecord)
+	}
+	for _, name := range allStages {
+		if _, ok := m.Stages[name]; !ok {
+			m.Stages[name] = &StageRecord{Name: name, Status: StatusPending}
+		}
+	}
+	if m.TraceID == "" {
+		m.TraceID = newTraceID()
+	}
+	return &m, nil
+}
+
+// save persists the manifest, guarding the marshal against the concurrent
+// mutation that beginStage/endStage/skipStage and the SIGINT handler can
+// both be doing at once.
+func (m *Manifest) save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saveLocked()
+}
+
+// saveLocked does the actual marshal+write; callers must hold m.mu.
+func (m *Manifest) saveLocked() error {
+	m.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+	return ioutil.WriteFile(m.path, data, 0644)
+}
+
+// isDone reports whether a stage already completed successfully on a prior
+// run, so --resume can skip it.
+func (m *Manifest) isDone(name StageName) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.Stages[name]
+	return ok && rec.Status == StatusCompleted
+}
+
+// beginStage marks a stage as running and persists the manifest immediately,
+// so a crash mid-stage still records that it was attempted.
+func (m *Manifest) beginStage(name StageName, command string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec := m.Stages[name]
+	rec.Status = StatusRunning
+	rec.Command = command
+	rec.StartedAt = time.Now()
+	rec.EndedAt = time.Time{}
+	rec.Error = ""
+	if err := m.saveLocked(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to save manifest: %v\n", err)
+	}
+}
+
+// endStage records the outcome of a stage and persists the manifest.
+func (m *Manifest) endStage(name StageName, err error, outputs ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec := m.Stages[name]
+	rec.EndedAt = time.Now()
+	rec.Outputs = outputs
+	rec.ExitCode = exitCodeFromErr(err)
+	if err != nil {
+		rec.Status = StatusFailed
+		rec.Error = err.Error()
+	} else {
+		rec.Status = StatusCompleted
+	}
+	if saveErr := m.saveLocked(); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to save manifest: %v\n", saveErr)
+	}
+}
+
+// exitCodeFromErr recovers the external command's exit code from a stage
+// error, so a failed massdns/naabu/nuclei run is recorded accurately
+// instead of the zero value reading as a clean exit. Stage errors that
+// didn't come from a command (a failed file read, say) report -1.
+func exitCodeFromErr(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// skipStage marks a stage as skipped (deselected via --skip or --only, or
+// local_audit in daemon jobs) without touching its timestamps.
+func (m *Manifest) skipStage(name StageName) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec := m.Stages[name]
+	rec.Status = StatusSkipped
+	if err := m.saveLocked(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to save manifest: %v\n", err)
+	}
+}
+
+// stageSet parses a comma-separated --only/--skip flag value into a lookup
+// set of StageNames. An empty string yields a nil (unrestricted) set.
+func stageSet(flagValue string) map[StageName]bool {
+	if flagValue == "" {
+		return nil
+	}
+	set := make(map[StageName]bool)
+	for _, part := range strings.Split(flagValue, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			set[StageName(part)] = true
+		}
+	}
+	return set
+}
+
+// Run is a single recon scan in progress: the inputs that started it plus
+// the manifest tracking how far it has gotten.
+type Run struct {
+	Domain        string
+	ResolversFile string
+	ResultDir     string
+	Manifest      *Manifest
+	Only          map[StageName]bool
+	Skip          map[StageName]bool
+	Logger        *slog.Logger
+}
+
+// shouldRun decides whether a stage needs to execute, taking --resume
+// (already-completed stages are skipped), --only and --skip into account.
+func (r *Run) shouldRun(stage StageName) bool {
+	if r.Skip != nil && r.Skip[stage] {
+		return false
+	}
+	if r.Only != nil && !r.Only[stage] {
+		return false
+	}
+	if r.Manifest.isDone(stage) {
+		return false
+	}
+	return true
+}
+
+// runStage executes fn if the stage is selected to run, recording its start,
+// outputs and outcome in the manifest. A stage that is not selected is
+// marked skipped, unless it already completed on a prior run, in which case
+// its record is left as it is. A stage error is logged
+// and the run moves on to the next stage rather than aborting the whole
+// recon, the way a single log.Fatal used to.
+func (r *Run) runStage(stage StageName, command string, fn func() ([]string, error)) {
+	log := r.Logger.With("stage", string(stage))
+
+	if !r.shouldRun(stage) {
+		if r.Manifest.isDone(stage) {
+			log.Info("skipping stage, already completed")
+		} else {
+			log.Info("skipping stage")
+			r.Manifest.skipStage(stage)
+		}
+		return
+	}
+
+	log.Info("stage starting", "command", command)
+	r.Manifest.beginStage(stage, command)
+	outputs, err := fn()
+	r.Manifest.endStage(stage, err, outputs...)
+	if err != nil {
+		log.Error("stage failed, continuing with remaining stages", "error", err)
+		return
+	}
+	log.Info("stage completed", "outputs", outputs)
+}
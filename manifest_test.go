@@ -0,0 +1,41 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestManifestConcurrentSave exercises the exact race the SIGINT handler can
+// hit: stage bookkeeping and an unrelated goroutine calling save()
+// concurrently. Run with -race; it guards against regressing Manifest back
+// to being unsafe for concurrent use.
+func TestManifestConcurrentSave(t *testing.T) {
+	m := newManifest("example.com", "resolvers.txt", t.TempDir())
+
+	var wg sync.WaitGroup
+	for _, stage := range allStages {
+		stage := stage
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.beginStage(stage, "test-command")
+			m.endStage(stage, nil, "output.txt")
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := m.save(); err != nil {
+			t.Errorf("concurrent save failed: %v", err)
+		}
+	}()
+
+	wg.Wait()
+
+	for _, stage := range allStages {
+		if !m.isDone(stage) {
+			t.Errorf("stage %s not marked completed after concurrent run", stage)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -13,8 +15,13 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
+
+	"github.com/aikazu/reconbulk/localaudit"
+	"github.com/aikazu/reconbulk/sources"
+	"github.com/aikazu/reconbulk/sources/crtsh"
 )
 
 type Config struct {
@@ -25,6 +32,8 @@ type Config struct {
 	Httpx       CommandConfig `json:"httpx"`
 	Naabu       CommandConfig `json:"naabu"`
 	Nuclei      CommandConfig `json:"nuclei"`
+	Crtsh       CrtshConfig   `json:"crtsh"`
+	Sources     SourcesConfig `json:"sources"`
 }
 
 type CommandConfig struct {
@@ -32,16 +41,36 @@ type CommandConfig struct {
 	Args    []string `json:"args"`
 }
 
+// CrtshConfig configures the native crt.sh HTTP client. PGConnString is
+// optional and only used when the JSON endpoint keeps failing.
+type CrtshConfig struct {
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	MaxRetries     int    `json:"max_retries"`
+	PGConnString   string `json:"pg_conn_string"`
+}
+
+// SourcesConfig selects which registered subdomain sources run. An empty
+// Enabled list runs every registered source.
+type SourcesConfig struct {
+	Enabled []string `json:"enabled"`
+}
+
 var config Config
 
+// logger is set up in runScan once the run's trace ID is known (runServe
+// sets up its own for the daemon); every package
+// function logs through it instead of fmt.Println/log.Fatal.
+var logger *slog.Logger
+
 func loadConfig(configPath string) {
 	data, err := ioutil.ReadFile(configPath)
 	if err != nil {
-		log.Fatalf("Failed to read config file: %v", err)
+		logger.Error("failed to read config file", "path", configPath, "error", err)
+		os.Exit(1)
 	}
-	err = json.Unmarshal(data, &config)
-	if err != nil {
-		log.Fatalf("Failed to parse config file: %v", err)
+	if err := json.Unmarshal(data, &config); err != nil {
+		logger.Error("failed to parse config file", "path", configPath, "error", err)
+		os.Exit(1)
 	}
 }
 
@@ -68,7 +97,8 @@ func banner() {
 
 func checkErr(err error) {
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("fatal error", "error", err)
+		os.Exit(1)
 	}
 }
 
@@ -77,83 +107,108 @@ func showOutputInRealTime(cmd *exec.Cmd) {
 	cmd.Stderr = os.Stderr
 }
 
-func executeCmd(cmd *exec.Cmd, sleepSeconds int) {
-	showOutputInRealTime(cmd)
-	time.Sleep(time.Duration(sleepSeconds) * time.Second)
-	cmd.Run()
+// buildSourceRegistry registers the four external tools reconbulk has
+// always shelled out to, plus the pure-Go sources that need no extra
+// binary. crt.sh is deliberately not registered here: it has its own
+// dedicated stage (see scanCRT) that also emits certificate metadata, not
+// just a subdomain list.
+func buildSourceRegistry(resolversFile string) *sources.Registry {
+	registry := sources.NewRegistry()
+	registry.Register(sources.NewCommandSource("amass", sources.CommandSpec{Command: config.Amass.Command, Args: config.Amass.Args}, resolversFile))
+	registry.Register(sources.NewCommandSource("subfinder", sources.CommandSpec{Command: config.Subfinder.Command, Args: config.Subfinder.Args}, resolversFile))
+	registry.Register(sources.NewCommandSource("assetfinder", sources.CommandSpec{Command: config.Assetfinder.Command, Args: config.Assetfinder.Args}, ""))
+	registry.Register(sources.NewCommandSource("findomain", sources.CommandSpec{Command: config.Findomain.Command, Args: config.Findomain.Args}, resolversFile))
+	registry.Register(sources.NewWaybackSource())
+	registry.Register(sources.NewHackerTargetSource())
+	registry.Register(sources.NewAlienVaultSource())
+	return registry
 }
 
-func startCmd(commandConfig CommandConfig, domain, resultDir, outputFile, resolversFile string) *exec.Cmd {
-	cmdArgs := make([]string, len(commandConfig.Args))
-	copy(cmdArgs, commandConfig.Args)
-	for i := 0; i < len(cmdArgs); i++ {
-		cmdArgs[i] = strings.Replace(cmdArgs[i], "{domain}", domain, -1)
-		cmdArgs[i] = strings.Replace(cmdArgs[i], "{dir}", resultDir, -1)
-		cmdArgs[i] = strings.Replace(cmdArgs[i], "{output}", outputFile, -1)
-		cmdArgs[i] = strings.Replace(cmdArgs[i], "{resolvers}", resolversFile, -1)
+// findSubdomains runs every enabled, available source concurrently, bounded
+// by governor, and returns the output files that were actually written.
+// Sources missing their binary (or otherwise unavailable) are skipped with
+// a warning instead of being left to fail and produce an empty file.
+func findSubdomains(domain, resultDir string, registry *sources.Registry, enabled []string, governor *Governor) []string {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var outputs []string
+
+	for _, src := range registry.Enabled(enabled) {
+		if !src.Available() {
+			logger.Warn("skipping subdomain source, not available", "source", src.Name())
+			continue
+		}
+
+		src := src
+		outFile := filepath.Join(resultDir, fmt.Sprintf("%s_%s.txt", src.Name(), domain))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			governor.Acquire()
+			defer governor.Release()
+			if err := src.Run(context.Background(), domain, outFile); err != nil {
+				logger.Error("subdomain source failed", "source", src.Name(), "error", err)
+				return
+			}
+			mu.Lock()
+			outputs = append(outputs, outFile)
+			mu.Unlock()
+		}()
 	}
-	cmd := exec.Command(commandConfig.Command, cmdArgs...)
-	showOutputInRealTime(cmd)
-	return cmd
-}
 
-func startAmass(domain, resolversFile, resultDir string) *exec.Cmd {
-	outputFile := filepath.Join(resultDir, fmt.Sprintf("amass_%s.txt", domain))
-	return startCmd(config.Amass, domain, resultDir, outputFile, resolversFile)
+	wg.Wait()
+	return outputs
 }
 
-func startSubfinder(domain, resolversFile, resultDir string) *exec.Cmd {
-	outputFile := filepath.Join(resultDir, fmt.Sprintf("subfinder_%s.txt", domain))
-	return startCmd(config.Subfinder, domain, resultDir, outputFile, resolversFile)
-}
+func scanCRT(domain, resultDir string) error {
+	logger.Info("scanning crt.sh", "domain", domain)
+	crtOutput := filepath.Join(resultDir, fmt.Sprintf("%s.crt.txt", domain))
+	jsonlOutput := filepath.Join(resultDir, fmt.Sprintf("crtsh_%s.jsonl", domain))
 
-func startAssetfinder(domain, resultDir string) *exec.Cmd {
-	outputFile := filepath.Join(resultDir, fmt.Sprintf("assetfinder_%s.txt", domain))
-	return startCmd(config.Assetfinder, domain, resultDir, outputFile, "")
-}
+	timeout := 30 * time.Second
+	if config.Crtsh.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.Crtsh.TimeoutSeconds) * time.Second
+	}
+	client := crtsh.NewClient(timeout)
+	if config.Crtsh.MaxRetries > 0 {
+		client.MaxRetries = config.Crtsh.MaxRetries
+	}
+	client.PGConnString = config.Crtsh.PGConnString
 
-func startFindomain(domain, resolversFile, resultDir string) *exec.Cmd {
-	outputFile := filepath.Join(resultDir, fmt.Sprintf("findomain_%s.txt", domain))
-	return startCmd(config.Findomain, domain, resultDir, outputFile, resolversFile)
-}
+	ctx, cancel := context.WithTimeout(context.Background(), client.RetryBudget(timeout))
+	defer cancel()
 
-func findSubdomains(domain, resolversFile, resultDir string) (*exec.Cmd, *exec.Cmd, *exec.Cmd, *exec.Cmd) {
-	amassCmd := startAmass(domain, resolversFile, resultDir)
-	subfinderCmd := startSubfinder(domain, resolversFile, resultDir)
-	assetfinderCmd := startAssetfinder(domain, resultDir)
-	findomainCmd := startFindomain(domain, resolversFile, resultDir)
+	entries, err := client.Fetch(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("scan crt.sh: %w", err)
+	}
 
-	go executeCmd(amassCmd, 5)
-	go executeCmd(subfinderCmd, 5)
-	go executeCmd(assetfinderCmd, 5)
-	go executeCmd(findomainCmd, 5)
+	if err := writeUniqueSubdomainsToFile(crtOutput, crtsh.Subdomains(entries)); err != nil {
+		return fmt.Errorf("write crt.sh subdomains: %w", err)
+	}
+	logger.Info("crt.sh results written", "path", crtOutput)
 
-	return amassCmd, subfinderCmd, assetfinderCmd, findomainCmd
+	if err := writeCertificatesJSONL(jsonlOutput, crtsh.GroupByCertificate(entries)); err != nil {
+		return fmt.Errorf("write crt.sh jsonl: %w", err)
+	}
+	logger.Info("crt.sh certificate metadata written", "path", jsonlOutput)
+	return nil
 }
 
-func scanCRT(domain, resultDir string) {
-	fmt.Println("Scanning crt.sh...")
-	crtOutput := filepath.Join(resultDir, fmt.Sprintf("%s.crt.txt", domain))
-	crtURL := fmt.Sprintf("https://crt.sh/?q=%%.%s&output=json", domain)
-
-	response, err := exec.Command("curl", "-s", crtURL).Output()
-	checkErr(err)
-
-	var data []map[string]interface{}
-	json.Unmarshal(response, &data)
-
-	uniqueSubdomains := make(map[string]struct{})
+func writeCertificatesJSONL(filename string, certs []crtsh.Certificate) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-	for _, entry := range data {
-		nameValue := entry["name_value"].(string)
-		if nameValue != "" {
-			nameValue = strings.Replace(nameValue, "*.", "", -1)
-			uniqueSubdomains[nameValue] = struct{}{}
+	enc := json.NewEncoder(f)
+	for _, cert := range certs {
+		if err := enc.Encode(cert); err != nil {
+			return err
 		}
 	}
-
-	writeUniqueSubdomainsToFile(crtOutput, uniqueSubdomains)
-	fmt.Printf("crt.sh results written to: %s\n", crtOutput)
+	return nil
 }
 
 func fileExists(filename string) bool {
@@ -161,22 +216,18 @@ func fileExists(filename string) bool {
 	return !os.IsNotExist(err)
 }
 
-func combineSubdomains(domain, resultDir string) {
-	fmt.Println("Combining subdomains...")
-	amassOutput := filepath.Join(resultDir, fmt.Sprintf("amass_%s.txt", domain))
-	subfinderOutput := filepath.Join(resultDir, fmt.Sprintf("subfinder_%s.txt", domain))
-	assetfinderOutput := filepath.Join(resultDir, fmt.Sprintf("assetfinder_%s.txt", domain))
-	findomainOutput := filepath.Join(resultDir, fmt.Sprintf("findomain_%s.txt", domain))
-	crtOutput := filepath.Join(resultDir, fmt.Sprintf("%s.crt.txt", domain))
+func combineSubdomains(domain, resultDir string, subdomainFiles []string) error {
+	logger.Info("combining subdomains")
 	subdomainsOutput := filepath.Join(resultDir, fmt.Sprintf("%s.subdomains.txt", domain))
 
-	subdomainFiles := []string{amassOutput, subfinderOutput, assetfinderOutput, findomainOutput, crtOutput}
 	uniqueSubdomains := make(map[string]struct{})
 
 	for _, file := range subdomainFiles {
 		if fileExists(file) {
 			lines, err := ioutil.ReadFile(file)
-			checkErr(err)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", file, err)
+			}
 			for _, line := range strings.Split(string(lines), "\n") {
 				line = strings.TrimSpace(line)
 				if line != "" {
@@ -184,33 +235,38 @@ func combineSubdomains(domain, resultDir string) {
 				}
 			}
 		} else {
-			fmt.Printf("File not found: %s\n", file)
+			logger.Debug("source file not found, skipping", "path", file)
 		}
 	}
 
-	writeUniqueSubdomainsToFile(subdomainsOutput, uniqueSubdomains)
-	fmt.Printf("Combined subdomains written to: %s\n", subdomainsOutput)
+	if err := writeUniqueSubdomainsToFile(subdomainsOutput, uniqueSubdomains); err != nil {
+		return fmt.Errorf("write %s: %w", subdomainsOutput, err)
+	}
+	logger.Info("combined subdomains written", "path", subdomainsOutput)
+	return nil
 }
 
-func writeUniqueSubdomainsToFile(filename string, uniqueSubdomains map[string]struct{}) {
+func writeUniqueSubdomainsToFile(filename string, uniqueSubdomains map[string]struct{}) error {
 	subdomains := make([]string, 0, len(uniqueSubdomains))
 	for subdomain := range uniqueSubdomains {
 		subdomains = append(subdomains, subdomain)
 	}
 	sort.Strings(subdomains)
 
-	err := ioutil.WriteFile(filename, []byte(strings.Join(subdomains, "\n")+"\n"), 0644)
-	checkErr(err)
+	return ioutil.WriteFile(filename, []byte(strings.Join(subdomains, "\n")+"\n"), 0644)
 }
 
-func findIPs(domain, resolversFile, resultDir string) {
-	fmt.Println("Now finding IPs for subdomains...")
+func findIPs(domain, resolversFile, resultDir string) error {
+	logger.Info("finding IPs for subdomains")
 	subdomainsOutput := filepath.Join(resultDir, fmt.Sprintf("%s.subdomains.txt", domain))
 	ipsOutput := filepath.Join(resultDir, fmt.Sprintf("%s.ips.txt", domain))
 	cmd := exec.Command(filepath.Join("tools", "massdns"), "-r", resolversFile, "-t", "A", "-o", "S", "-w", ipsOutput, subdomainsOutput)
 	showOutputInRealTime(cmd)
-	cmd.Run()
-	fmt.Printf("IPs written to: %s\n", ipsOutput)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run massdns: %w", err)
+	}
+	logger.Info("IPs written", "path", ipsOutput)
+	return nil
 }
 
 func stripANSI(text string) string {
@@ -222,20 +278,22 @@ func stripBrackets(text string) string {
 	return strings.Replace(strings.Replace(text, "[", "", -1), "]", "", -1)
 }
 
-func scanHttpx(domain, resultDir string) {
-	fmt.Println("Scanning subdomains with httpx...")
+func scanHttpx(domain, resultDir string) error {
+	logger.Info("scanning subdomains with httpx")
 	subdomainsOutput := filepath.Join(resultDir, fmt.Sprintf("%s.subdomains.txt", domain))
 	httpxOutput := filepath.Join(resultDir, fmt.Sprintf("httpx_%s.txt", domain))
 	cmd := exec.Command(filepath.Join("tools", "httpx"), "-l", subdomainsOutput, "-title", "-tech-detect", "-status-code", "-o", httpxOutput)
 	showOutputInRealTime(cmd)
 	cmd.Run()
-	fmt.Printf("Httpx results written to: %s\n", httpxOutput)
+	logger.Info("httpx results written", "path", httpxOutput)
 
-	fmt.Println("Sorting httpx results...")
+	logger.Info("sorting httpx results")
 	sortedHttpxOutput := filepath.Join(resultDir, fmt.Sprintf("sorted_httpx_%s.txt", domain))
 
 	linesBytes, err := ioutil.ReadFile(httpxOutput)
-	checkErr(err)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", httpxOutput, err)
+	}
 	lines := strings.Split(string(linesBytes), "\n")
 
 	strippedLines := make([]string, 0, len(lines))
@@ -269,76 +327,285 @@ func scanHttpx(domain, resultDir string) {
 		withUrls = append(withUrls, url)
 	}
 
-	err = ioutil.WriteFile(sortedHttpxOutput, []byte(strings.Join(withUrls, "\n")+"\n"), 0644)
-	checkErr(err)
-	fmt.Printf("Sorted httpx results written to: %s\n", sortedHttpxOutput)
+	if err := ioutil.WriteFile(sortedHttpxOutput, []byte(strings.Join(withUrls, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", sortedHttpxOutput, err)
+	}
+	logger.Info("sorted httpx results written", "path", sortedHttpxOutput)
+	return nil
+}
+
+// scanLocalAudit enumerates the host's own listening sockets, giving an
+// operator with shell access ground truth for ports a firewall might hide
+// from naabu. It's opt-in via --local-audit since it only makes sense when
+// the target resolves to a host the operator controls.
+func scanLocalAudit(domain, resultDir string) (string, error) {
+	logger.Info("auditing local listening sockets")
+	listeners, err := localaudit.Gather()
+	if err != nil {
+		return "", fmt.Errorf("gather local listeners: %w", err)
+	}
+
+	outputFile := filepath.Join(resultDir, fmt.Sprintf("local_listeners_%s.txt", domain))
+	lines := make([]string, 0, len(listeners))
+	for _, l := range listeners {
+		lines = append(lines, fmt.Sprintf("%s:%d\t%s\t%d\t%s", l.Address, l.Port, l.Proto, l.PID, l.Program))
+	}
+	sort.Strings(lines)
+
+	if err := ioutil.WriteFile(outputFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return "", err
+	}
+	logger.Info("local listeners written", "path", outputFile, "count", len(lines))
+	return outputFile, nil
+}
+
+// mergeLocalListenersIntoNaabu folds the host:port column of
+// local_listeners_<domain>.txt into naabu's output, so the local ground
+// truth becomes part of the authoritative port list nuclei scans next.
+func mergeLocalListenersIntoNaabu(naabuOutput, localListenersOutput string) error {
+	if !fileExists(localListenersOutput) {
+		return nil
+	}
+
+	existing := make(map[string]struct{})
+	if fileExists(naabuOutput) {
+		data, err := ioutil.ReadFile(naabuOutput)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				existing[line] = struct{}{}
+			}
+		}
+	}
+
+	data, err := ioutil.ReadFile(localListenersOutput)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		hostPort := strings.SplitN(line, "\t", 2)[0]
+		existing[hostPort] = struct{}{}
+	}
+
+	merged := make([]string, 0, len(existing))
+	for hostPort := range existing {
+		merged = append(merged, hostPort)
+	}
+	sort.Strings(merged)
+
+	return ioutil.WriteFile(naabuOutput, []byte(strings.Join(merged, "\n")+"\n"), 0644)
 }
 
-func scanNaabu(domain, resultDir string) {
-	fmt.Println("Scanning subdomains with naabu...")
+func scanNaabu(domain, resultDir string) error {
+	logger.Info("scanning subdomains with naabu")
 	sortedHttpxOutput := filepath.Join(resultDir, fmt.Sprintf("sorted_httpx_%s.txt", domain))
 	naabuOutput := filepath.Join(resultDir, fmt.Sprintf("naabu_%s.txt", domain))
 	cmd := exec.Command(filepath.Join("tools", "naabu"), "-list", sortedHttpxOutput, "-o", naabuOutput)
 	showOutputInRealTime(cmd)
-	cmd.Run()
-	fmt.Printf("Naabu results written to: %s\n", naabuOutput)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run naabu: %w", err)
+	}
+	logger.Info("naabu results written", "path", naabuOutput)
+	return nil
 }
 
-func scanNuclei(domain, resultDir string) {
-	fmt.Println("Scanning subdomains with nuclei...")
+func scanNuclei(domain, resultDir string) error {
+	logger.Info("scanning subdomains with nuclei")
 	sortedHttpxOutput := filepath.Join(resultDir, fmt.Sprintf("sorted_httpx_%s.txt", domain))
 	nucleiOutput := filepath.Join(resultDir, fmt.Sprintf("nuclei_%s.txt", domain))
 	cmd := exec.Command(filepath.Join("tools", "nuclei"), "-list", sortedHttpxOutput, "-o", nucleiOutput)
 	showOutputInRealTime(cmd)
-	cmd.Run()
-	fmt.Printf("Nuclei results written to: %s\n", nucleiOutput)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run nuclei: %w", err)
+	}
+	logger.Info("nuclei results written", "path", nucleiOutput)
+	return nil
 }
 
+func usage() {
+	fmt.Println("Usage : ./reconbulk [flags] domain resolvers_list")
+	fmt.Println("        ./reconbulk --resume <resultDir> [flags]")
+	fmt.Println("        ./reconbulk serve [flags]")
+	fmt.Println("        ./reconbulk client [flags] domain resolvers_list")
+	flag.PrintDefaults()
+}
+
+// main dispatches to the daemon and its CLI client when invoked as
+// "reconbulk serve"/"reconbulk client", falling back to the original
+// one-shot scan for every other invocation so existing scripts and muscle
+// memory keep working untouched.
 func main() {
-	if len(os.Args) < 3 {
-		fmt.Println("2nd argument not supplied")
-		fmt.Println("2nd argument is the resolver file list path")
-		fmt.Println("Usage : ./reconbulk domain resolvers_list")
-		os.Exit(1)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "client":
+			runClient(os.Args[2:])
+			return
+		}
+	}
+	runScan()
+}
+
+func runScan() {
+	resumeDir := flag.String("resume", "", "resume a previous run from its result directory, reading manifest.json")
+	onlyFlag := flag.String("only", "", "comma-separated list of stages to run (default: all)")
+	skipFlag := flag.String("skip", "", "comma-separated list of stages to skip")
+	maxParallel := flag.Int("max-parallel", 0, "hard ceiling on concurrent external tools (0 = no explicit ceiling)")
+	targetLoad := flag.Float64("target-load", 0.8, "target load factor used to size the concurrency governor")
+	loadPoll := flag.Duration("load-poll", 5*time.Second, "how often to re-read the host load average")
+	logLevel := flag.String("log-level", "info", "log level: debug, info, warn, error")
+	logFormat := flag.String("log-format", "text", "log format: text or json")
+	localAudit := flag.Bool("local-audit", false, "enumerate the host's own listening sockets as ground truth before scanning (opt-in: only useful when the target resolves to a host you operate)")
+	flag.Usage = usage
+	flag.Parse()
+
+	logger = newLogger(*logLevel, *logFormat, "-")
+
+	var domain, resolversFile, resultDir string
+	var manifest *Manifest
+
+	if *resumeDir != "" {
+		resultDir = *resumeDir
+		m, err := loadManifest(resultDir)
+		checkErr(err)
+		manifest = m
+		domain = manifest.Domain
+		resolversFile = manifest.ResolversFile
+		logger.Info("resuming run", "domain", domain, "result_dir", resultDir)
+	} else {
+		args := flag.Args()
+		if len(args) < 2 {
+			fmt.Println("2nd argument not supplied")
+			fmt.Println("2nd argument is the resolver file list path")
+			usage()
+			os.Exit(1)
+		}
+		domain = args[0]
+		resolversFile = args[1]
+		dt := time.Now().Format("2006-01-02.15.04.05")
+		reconDir := filepath.Join(os.Getenv("HOME"), "recon")
+		resultDir = filepath.Join(reconDir, fmt.Sprintf("results/%s-%s", domain, dt))
+		os.MkdirAll(resultDir, os.ModePerm)
+		manifest = newManifest(domain, resolversFile, resultDir)
+		checkErr(manifest.save())
 	}
 
+	logger = newLogger(*logLevel, *logFormat, manifest.TraceID)
+
 	configPath := "config.json"
 	loadConfig(configPath)
 
-	domain := os.Args[1]
-	resolversFile := os.Args[2]
-	dt := time.Now().Format("2006-01-02.15.04.05")
-	reconDir := filepath.Join(os.Getenv("HOME"), "recon")
-	resultDir := filepath.Join(reconDir, fmt.Sprintf("results/%s-%s", domain, dt))
-	os.MkdirAll(resultDir, os.ModePerm)
+	governor := newGovernor(*maxParallel, *targetLoad, *loadPoll)
+	registry := buildSourceRegistry(resolversFile)
+
+	skip := stageSet(*skipFlag)
+	if !*localAudit {
+		if skip == nil {
+			skip = make(map[StageName]bool)
+		}
+		skip[StageLocalAudit] = true
+	}
+
+	run := &Run{
+		Domain:        domain,
+		ResolversFile: resolversFile,
+		ResultDir:     resultDir,
+		Manifest:      manifest,
+		Only:          stageSet(*onlyFlag),
+		Skip:          skip,
+		Logger:        logger,
+	}
 
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-signalChan
-		fmt.Println("Keyboard interrupt detected. Exiting script...")
+		logger.Warn("interrupt received, saving manifest and exiting")
+		if err := manifest.save(); err != nil {
+			logger.Error("failed to save manifest", "error", err)
+		}
+		logger.Warn("resume with", "command", fmt.Sprintf("./reconbulk --resume %s", resultDir))
 		os.Exit(1)
 	}()
 
-	amassCmd, subfinderCmd, assetfinderCmd, findomainCmd := findSubdomains(domain, resolversFile, resultDir)
-	if amassCmd != nil {
-		amassCmd.Wait()
-	}
-	if subfinderCmd != nil {
-		subfinderCmd.Wait()
-	}
-	if assetfinderCmd != nil {
-		assetfinderCmd.Wait()
-	}
-	if findomainCmd != nil {
-		findomainCmd.Wait()
-	}
-
 	printBanner()
-	scanCRT(domain, resultDir)
-	combineSubdomains(domain, resultDir)
-	findIPs(domain, resolversFile, resultDir)
-	scanHttpx(domain, resultDir)
-	scanNaabu(domain, resultDir)
-	scanNuclei(domain, resultDir)
+
+	run.runStage(StageLocalAudit, "scanLocalAudit", func() ([]string, error) {
+		outputFile, err := scanLocalAudit(domain, resultDir)
+		if err != nil {
+			return nil, err
+		}
+		return []string{outputFile}, nil
+	})
+
+	run.runStage(StageSubdomainEnum, "findSubdomains", func() ([]string, error) {
+		outputs := findSubdomains(domain, resultDir, registry, config.Sources.Enabled, governor)
+		return outputs, nil
+	})
+
+	run.runStage(StageCRT, "scanCRT", func() ([]string, error) {
+		if err := scanCRT(domain, resultDir); err != nil {
+			return nil, err
+		}
+		return []string{
+			filepath.Join(resultDir, fmt.Sprintf("%s.crt.txt", domain)),
+			filepath.Join(resultDir, fmt.Sprintf("crtsh_%s.jsonl", domain)),
+		}, nil
+	})
+
+	run.runStage(StageCombine, "combineSubdomains", func() ([]string, error) {
+		subdomainFiles := make([]string, 0, len(registry.Enabled(config.Sources.Enabled))+1)
+		for _, src := range registry.Enabled(config.Sources.Enabled) {
+			subdomainFiles = append(subdomainFiles, filepath.Join(resultDir, fmt.Sprintf("%s_%s.txt", src.Name(), domain)))
+		}
+		subdomainFiles = append(subdomainFiles, filepath.Join(resultDir, fmt.Sprintf("%s.crt.txt", domain)))
+
+		if err := combineSubdomains(domain, resultDir, subdomainFiles); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(resultDir, fmt.Sprintf("%s.subdomains.txt", domain))}, nil
+	})
+
+	run.runStage(StageMassdns, "findIPs", func() ([]string, error) {
+		if err := findIPs(domain, resolversFile, resultDir); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(resultDir, fmt.Sprintf("%s.ips.txt", domain))}, nil
+	})
+
+	run.runStage(StageHttpx, "scanHttpx", func() ([]string, error) {
+		if err := scanHttpx(domain, resultDir); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(resultDir, fmt.Sprintf("sorted_httpx_%s.txt", domain))}, nil
+	})
+
+	run.runStage(StageNaabu, "scanNaabu", func() ([]string, error) {
+		if err := scanNaabu(domain, resultDir); err != nil {
+			return nil, err
+		}
+		naabuOutput := filepath.Join(resultDir, fmt.Sprintf("naabu_%s.txt", domain))
+		if *localAudit {
+			localListenersOutput := filepath.Join(resultDir, fmt.Sprintf("local_listeners_%s.txt", domain))
+			if err := mergeLocalListenersIntoNaabu(naabuOutput, localListenersOutput); err != nil {
+				return nil, fmt.Errorf("merge local listeners into naabu results: %w", err)
+			}
+		}
+		return []string{naabuOutput}, nil
+	})
+
+	run.runStage(StageNuclei, "scanNuclei", func() ([]string, error) {
+		if err := scanNuclei(domain, resultDir); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(resultDir, fmt.Sprintf("nuclei_%s.txt", domain))}, nil
+	})
 }
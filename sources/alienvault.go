@@ -0,0 +1,62 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AlienVaultSource derives subdomains from AlienVault OTX's passive DNS
+// API, which reports every hostname OTX has ever seen resolve under the
+// domain.
+type AlienVaultSource struct {
+	HTTPClient *http.Client
+}
+
+// NewAlienVaultSource returns an AlienVaultSource that gives the OTX API
+// 30 seconds to respond.
+func NewAlienVaultSource() *AlienVaultSource {
+	return &AlienVaultSource{HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (a *AlienVaultSource) Name() string    { return "alienvault" }
+func (a *AlienVaultSource) Available() bool { return true }
+
+type alienVaultPassiveDNS struct {
+	PassiveDNS []struct {
+		Hostname string `json:"hostname"`
+	} `json:"passive_dns"`
+}
+
+func (a *AlienVaultSource) Run(ctx context.Context, domain, outFile string) error {
+	endpoint := fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/domain/%s/passive_dns", domain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("alienvault OTX returned %s", resp.Status)
+	}
+
+	var result alienVaultPassiveDNS
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	unique := make(map[string]struct{})
+	for _, record := range result.PassiveDNS {
+		if record.Hostname != "" {
+			unique[record.Hostname] = struct{}{}
+		}
+	}
+
+	return writeUniqueLines(outFile, unique)
+}
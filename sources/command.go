@@ -0,0 +1,60 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// CommandSpec is the external command backing a CommandSource: the same
+// {domain}/{dir}/{output}/{resolvers} placeholder substitution reconbulk has
+// always used for its config.json-defined tools.
+type CommandSpec struct {
+	Command string
+	Args    []string
+}
+
+// CommandSource adapts an external binary (amass, subfinder, assetfinder,
+// findomain, ...) to the Source interface.
+type CommandSource struct {
+	name          string
+	spec          CommandSpec
+	resolversFile string
+}
+
+// NewCommandSource builds a CommandSource. resolversFile is substituted for
+// the {resolvers} placeholder and may be empty for tools that don't take one.
+func NewCommandSource(name string, spec CommandSpec, resolversFile string) *CommandSource {
+	return &CommandSource{name: name, spec: spec, resolversFile: resolversFile}
+}
+
+func (c *CommandSource) Name() string { return c.name }
+
+// Available reports whether the configured binary is on PATH.
+func (c *CommandSource) Available() bool {
+	if c.spec.Command == "" {
+		return false
+	}
+	_, err := exec.LookPath(c.spec.Command)
+	return err == nil
+}
+
+func (c *CommandSource) Run(ctx context.Context, domain, outFile string) error {
+	replacer := strings.NewReplacer(
+		"{domain}", domain,
+		"{dir}", filepath.Dir(outFile),
+		"{output}", outFile,
+		"{resolvers}", c.resolversFile,
+	)
+	args := make([]string, len(c.spec.Args))
+	for i, arg := range c.spec.Args {
+		args[i] = replacer.Replace(arg)
+	}
+
+	cmd := exec.CommandContext(ctx, c.spec.Command, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,246 @@
+// Package crtsh is a native client for crt.sh's certificate transparency
+// search, replacing the old `curl | json` shell-out with a retrying,
+// streaming net/http client plus an optional PostgreSQL fallback for domains
+// where the JSON endpoint truncates large result sets.
+package crtsh
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+const (
+	defaultBaseURL   = "https://crt.sh"
+	defaultUserAgent = "reconbulk/1.0 (+https://github.com/aikazu/reconbulk)"
+)
+
+// Entry is one row of crt.sh's JSON output: a single identity observed on a
+// certificate.
+type Entry struct {
+	ID         int64  `json:"id"`
+	IssuerName string `json:"issuer_name"`
+	CommonName string `json:"common_name"`
+	NameValue  string `json:"name_value"`
+	NotBefore  string `json:"not_before"`
+	NotAfter   string `json:"not_after"`
+}
+
+// Certificate groups the Entry rows that share a crt.sh certificate ID into
+// a single record with the full SAN list, for the structured jsonl output.
+type Certificate struct {
+	ID         int64    `json:"id"`
+	IssuerName string   `json:"issuer_name"`
+	NotBefore  string   `json:"not_before"`
+	NotAfter   string   `json:"not_after"`
+	SANs       []string `json:"sans"`
+}
+
+// Client fetches certificate transparency data for a domain.
+type Client struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	UserAgent  string
+	MaxRetries int
+
+	// PGConnString, when set, is used as a fallback data source (querying
+	// crt.sh's public PostgreSQL mirror directly) when the JSON endpoint
+	// keeps failing, e.g. because it truncated a large response.
+	PGConnString string
+}
+
+// NewClient returns a client with the package defaults and the given
+// request timeout.
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: timeout},
+		BaseURL:    defaultBaseURL,
+		UserAgent:  defaultUserAgent,
+		MaxRetries: 4,
+	}
+}
+
+// RetryBudget returns the total time Fetch may need to exhaust MaxRetries
+// attempts against a single-request timeout of requestTimeout: the requests
+// themselves plus every backoff sleep between them. Callers size their own
+// context off this instead of a bare requestTimeout*(MaxRetries+1), which
+// would starve the backoff sleeps and cut retries short.
+func (c *Client) RetryBudget(requestTimeout time.Duration) time.Duration {
+	budget := requestTimeout * time.Duration(c.MaxRetries+1)
+	for attempt := 1; attempt <= c.MaxRetries; attempt++ {
+		budget += time.Duration(math.Pow(2, float64(attempt))) * time.Second
+	}
+	return budget
+}
+
+// Fetch returns every certificate transparency entry crt.sh has for domain,
+// retrying with exponential backoff on 5xx/429 responses before falling
+// back to PGConnString if one was configured.
+func (c *Client) Fetch(ctx context.Context, domain string) ([]Entry, error) {
+	url := fmt.Sprintf("%s/?q=%%.%s&output=json", c.BaseURL, domain)
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(math.Pow(2, float64(attempt))) * time.Second
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		entries, retryable, err := c.fetchOnce(ctx, url)
+		if err == nil {
+			return entries, nil
+		}
+		lastErr = err
+		if !retryable {
+			break
+		}
+	}
+
+	if c.PGConnString != "" {
+		entries, pgErr := c.fetchFromPostgres(ctx, domain)
+		if pgErr == nil {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("json endpoint failed (%v), postgres fallback failed: %w", lastErr, pgErr)
+	}
+
+	return nil, lastErr
+}
+
+func (c *Client) fetchOnce(ctx context.Context, url string) (entries []Entry, retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, true, fmt.Errorf("crt.sh returned %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("crt.sh returned %s", resp.Status)
+	}
+
+	entries, err = decodeEntries(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("decode crt.sh response: %w", err)
+	}
+	return entries, false, nil
+}
+
+// decodeEntries streams the top-level JSON array element by element so a
+// huge response body never has to be buffered in memory all at once.
+func decodeEntries(r io.Reader) ([]Entry, error) {
+	dec := json.NewDecoder(r)
+	if _, err := dec.Token(); err != nil { // consume the opening '['
+		return nil, err
+	}
+	var entries []Entry
+	for dec.More() {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// fetchFromPostgres queries crt.sh's schema directly via lib/pq for domains
+// where the JSON endpoint truncates.
+func (c *Client) fetchFromPostgres(ctx context.Context, domain string) ([]Entry, error) {
+	db, err := sql.Open("postgres", c.PGConnString)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	const query = `
+		SELECT c.ID, x509_issuerName(c.CERTIFICATE), ci.NAME_VALUE,
+		       x509_notBefore(c.CERTIFICATE), x509_notAfter(c.CERTIFICATE)
+		FROM certificate_and_identities ci
+		JOIN certificate c ON ci.CERTIFICATE_ID = c.ID
+		WHERE ci.NAME_VALUE ILIKE $1
+	`
+	rows, err := db.QueryContext(ctx, query, "%."+domain)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var notBefore, notAfter time.Time
+		if err := rows.Scan(&e.ID, &e.IssuerName, &e.NameValue, &notBefore, &notAfter); err != nil {
+			return nil, err
+		}
+		e.NotBefore = notBefore.Format(time.RFC3339)
+		e.NotAfter = notAfter.Format(time.RFC3339)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GroupByCertificate collapses the per-identity Entry rows into one record
+// per certificate, each carrying its full SAN list.
+func GroupByCertificate(entries []Entry) []Certificate {
+	byID := make(map[int64]*Certificate)
+	order := make([]int64, 0)
+
+	for _, e := range entries {
+		cert, ok := byID[e.ID]
+		if !ok {
+			cert = &Certificate{ID: e.ID, IssuerName: e.IssuerName, NotBefore: e.NotBefore, NotAfter: e.NotAfter}
+			byID[e.ID] = cert
+			order = append(order, e.ID)
+		}
+		for _, name := range strings.Split(e.NameValue, "\n") {
+			name = strings.TrimSpace(name)
+			if name != "" {
+				cert.SANs = append(cert.SANs, name)
+			}
+		}
+	}
+
+	certs := make([]Certificate, 0, len(order))
+	for _, id := range order {
+		certs = append(certs, *byID[id])
+	}
+	return certs
+}
+
+// Subdomains extracts the unique, wildcard-stripped subdomain names found
+// across every entry.
+func Subdomains(entries []Entry) map[string]struct{} {
+	unique := make(map[string]struct{})
+	for _, e := range entries {
+		for _, name := range strings.Split(e.NameValue, "\n") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			name = strings.Replace(name, "*.", "", -1)
+			unique[name] = struct{}{}
+		}
+	}
+	return unique
+}
@@ -0,0 +1,39 @@
+package crtsh
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryBudgetCoversBackoffSleeps(t *testing.T) {
+	c := NewClient(30 * time.Second)
+	c.MaxRetries = 4
+
+	requestTimeout := 30 * time.Second
+	var wantBackoff time.Duration
+	for attempt := 1; attempt <= c.MaxRetries; attempt++ {
+		wantBackoff += (1 << attempt) * time.Second // 2s, 4s, 8s, 16s
+	}
+	want := requestTimeout*time.Duration(c.MaxRetries+1) + wantBackoff
+
+	got := c.RetryBudget(requestTimeout)
+	if got != want {
+		t.Fatalf("RetryBudget(%s) = %s, want %s", requestTimeout, got, want)
+	}
+
+	// The budget must be strictly larger than charging every attempt's
+	// timeout alone, or the final retry's backoff sleep runs the context
+	// out of time before the request it's waiting to make.
+	if bare := requestTimeout * time.Duration(c.MaxRetries+1); got <= bare {
+		t.Fatalf("RetryBudget(%s) = %s, want more than the bare %s", requestTimeout, got, bare)
+	}
+}
+
+func TestRetryBudgetNoRetries(t *testing.T) {
+	c := NewClient(10 * time.Second)
+	c.MaxRetries = 0
+
+	if got, want := c.RetryBudget(10*time.Second), 10*time.Second; got != want {
+		t.Fatalf("RetryBudget with MaxRetries=0 = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HackerTargetSource queries HackerTarget's free hostsearch API, which
+// returns one "hostname,ip" line per known subdomain (and occasionally an
+// "API count exceeded" line in place of results, which Run filters out).
+type HackerTargetSource struct {
+	HTTPClient *http.Client
+}
+
+// NewHackerTargetSource returns a HackerTargetSource that gives the
+// hostsearch API 30 seconds to respond.
+func NewHackerTargetSource() *HackerTargetSource {
+	return &HackerTargetSource{HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (h *HackerTargetSource) Name() string    { return "hackertarget" }
+func (h *HackerTargetSource) Available() bool { return true }
+
+func (h *HackerTargetSource) Run(ctx context.Context, domain, outFile string) error {
+	endpoint := fmt.Sprintf("https://api.hackertarget.com/hostsearch/?q=%s", domain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("hackertarget hostsearch returned %s", resp.Status)
+	}
+
+	unique := make(map[string]struct{})
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "error") || strings.Contains(line, "API count exceeded") {
+			continue
+		}
+		host := strings.SplitN(line, ",", 2)[0]
+		if host != "" {
+			unique[host] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return writeUniqueLines(outFile, unique)
+}
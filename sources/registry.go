@@ -0,0 +1,72 @@
+// Package sources defines the pluggable subdomain-source registry: a Source
+// is anything that can discover subdomains for a domain and write them, one
+// per line, to an output file. Built-in adapters wrap the four external
+// tools reconbulk has always shelled out to plus a few pure-Go sources that
+// need no extra binaries.
+package sources
+
+import "context"
+
+// Source is one subdomain discovery method.
+type Source interface {
+	// Name identifies the source, used for its output filename and for
+	// config's sources.enabled list.
+	Name() string
+	// Run discovers subdomains for domain and writes them to outFile, one
+	// per line.
+	Run(ctx context.Context, domain, outFile string) error
+	// Available reports whether the source can run at all right now (e.g.
+	// its binary is on PATH). Unavailable sources are skipped with a
+	// warning rather than left to fail and produce an empty output file.
+	Available() bool
+}
+
+// Registry holds the known sources in registration order.
+type Registry struct {
+	sources map[string]Source
+	order   []string
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{sources: make(map[string]Source)}
+}
+
+// Register adds a source, replacing any previously registered source of the
+// same name.
+func (r *Registry) Register(s Source) {
+	if _, exists := r.sources[s.Name()]; !exists {
+		r.order = append(r.order, s.Name())
+	}
+	r.sources[s.Name()] = s
+}
+
+// All returns every registered source in registration order.
+func (r *Registry) All() []Source {
+	result := make([]Source, 0, len(r.order))
+	for _, name := range r.order {
+		result = append(result, r.sources[name])
+	}
+	return result
+}
+
+// Enabled returns the registered sources whose name appears in names,
+// preserving registration order. An empty names list returns every
+// registered source, matching reconbulk's historical behavior of always
+// running everything it knows about.
+func (r *Registry) Enabled(names []string) []Source {
+	if len(names) == 0 {
+		return r.All()
+	}
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		want[n] = true
+	}
+	result := make([]Source, 0, len(names))
+	for _, name := range r.order {
+		if want[name] {
+			result = append(result, r.sources[name])
+		}
+	}
+	return result
+}
@@ -0,0 +1,18 @@
+package sources
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// writeUniqueLines sorts and deduplicates lines before writing them to path,
+// matching the output format reconbulk's other stages already produce.
+func writeUniqueLines(path string, lines map[string]struct{}) error {
+	unique := make([]string, 0, len(lines))
+	for line := range lines {
+		unique = append(unique, line)
+	}
+	sort.Strings(unique)
+	return ioutil.WriteFile(path, []byte(strings.Join(unique, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,59 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WaybackSource recovers subdomains the Wayback Machine has ever crawled by
+// scanning its CDX index for every archived URL under the domain.
+type WaybackSource struct {
+	HTTPClient *http.Client
+}
+
+// NewWaybackSource returns a WaybackSource that times out CDX requests
+// after 30 seconds.
+func NewWaybackSource() *WaybackSource {
+	return &WaybackSource{HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (w *WaybackSource) Name() string    { return "wayback" }
+func (w *WaybackSource) Available() bool { return true }
+
+func (w *WaybackSource) Run(ctx context.Context, domain, outFile string) error {
+	endpoint := fmt.Sprintf("http://web.archive.org/cdx/search/cdx?url=*.%s&output=text&fl=original&collapse=urlkey", domain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wayback CDX returned %s", resp.Status)
+	}
+
+	unique := make(map[string]struct{})
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		u, err := url.Parse(scanner.Text())
+		if err != nil {
+			continue
+		}
+		if host := u.Hostname(); host != "" {
+			unique[host] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return writeUniqueLines(outFile, unique)
+}